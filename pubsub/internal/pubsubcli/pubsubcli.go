@@ -0,0 +1,111 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+// Package pubsubcli holds client construction and flag helpers shared by
+// the pubsub command-line samples, so that emulator and service-account
+// support only needs to be implemented once.
+package pubsubcli
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2/google"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// CommonFlags registers the -p, -emulator, and -json flags shared by the
+// pubsub samples.
+func CommonFlags(fs *flag.FlagSet) (proj, emulator, jsonKeyPath *string) {
+	proj = fs.String("p", "", "GCP project ID")
+	emulator = fs.String("emulator", "", "address of a local Pub/Sub emulator or pstest server to use instead of the live API")
+	jsonKeyPath = fs.String("json", "", "path to a service account JSON key file to authenticate with, instead of Application Default Credentials")
+	return proj, emulator, jsonKeyPath
+}
+
+// NewClient creates a pubsub.Client for proj, falling back to the
+// GOOGLE_CLOUD_PROJECT environment variable when proj is empty. If
+// emulatorAddr is set, or PUBSUB_EMULATOR_HOST is, the client talks to that
+// address instead of the live Pub/Sub API and jsonKeyPath is ignored.
+// Otherwise, if jsonKeyPath is set, the client authenticates with that
+// service account key instead of Application Default Credentials.
+func NewClient(ctx context.Context, proj, emulatorAddr, jsonKeyPath string) *pubsub.Client {
+	if proj == "" {
+		proj = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if emulatorAddr == "" {
+		emulatorAddr = os.Getenv("PUBSUB_EMULATOR_HOST")
+	}
+	if emulatorAddr != "" {
+		conn, err := grpc.Dial(emulatorAddr, grpc.WithInsecure())
+		if err != nil {
+			log.Fatalf("Could not connect to Pub/Sub emulator at %s: %v", emulatorAddr, err)
+		}
+		if proj == "" {
+			proj = "emulator-project"
+		}
+		client, err := pubsub.NewClient(ctx, proj, option.WithGRPCConn(conn))
+		if err != nil {
+			log.Fatalf("Could not create pubsub Client: %v", err)
+		}
+		return client
+	}
+
+	if proj == "" {
+		log.Fatal("Project ID must be set with -p or the GOOGLE_CLOUD_PROJECT environment variable.")
+	}
+
+	if jsonKeyPath != "" {
+		data, err := ioutil.ReadFile(jsonKeyPath)
+		if err != nil {
+			log.Fatalf("Could not read service account JSON key file: %v", err)
+		}
+		conf, err := google.JWTConfigFromJSON(data, pubsub.ScopeCloudPlatform)
+		if err != nil {
+			log.Fatalf("Could not parse service account JSON key file: %v", err)
+		}
+		client, err := pubsub.NewClient(ctx, proj, option.WithTokenSource(conf.TokenSource(ctx)))
+		if err != nil {
+			log.Fatalf("Could not create pubsub Client: %v", err)
+		}
+		return client
+	}
+
+	// [START auth]
+	client, err := pubsub.NewClient(ctx, proj)
+	if err != nil {
+		log.Fatalf("Could not create pubsub Client: %v", err)
+	}
+	// [END auth]
+	return client
+}
+
+// AttrFlag accumulates repeated -push-attr key=value flags into a map, for
+// use with flag.Var.
+type AttrFlag map[string]string
+
+func (a AttrFlag) String() string {
+	var pairs []string
+	for k, v := range a {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (a AttrFlag) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid -push-attr %q, want key=value", s)
+	}
+	a[parts[0]] = parts[1]
+	return nil
+}
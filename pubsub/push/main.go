@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+// Command push runs an HTTP server that receives Google Cloud Pub/Sub push
+// messages and acknowledges or rejects them based on how it decodes them.
+// See more about Pub/Sub push subscriptions at
+// https://cloud.google.com/pubsub/docs/push.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsub/internal/pubsubcli"
+)
+
+func main() {
+	fs := flag.CommandLine
+	proj, emulator, jsonKeyPath := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the push subscription to create; leave empty to only run the receiver")
+	topicName := fs.String("topic", "", "name of the topic to subscribe to, required with -sub")
+	endpoint := fs.String("endpoint", "", "publicly reachable URL that Pub/Sub should push messages to, required with -sub")
+	pushAttrs := pubsubcli.AttrFlag{}
+	fs.Var(pushAttrs, "push-attr", "push endpoint attribute key=value; repeatable, only used with -sub")
+	listen := fs.String("listen", ":8080", "address for the push receiver to listen on")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	if *subName != "" {
+		if *topicName == "" || *endpoint == "" {
+			log.Fatal("-topic and -endpoint are required with -sub")
+		}
+		client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKeyPath)
+		push := &pubsub.PushConfig{Endpoint: *endpoint, Attributes: pushAttrs}
+		sub, err := client.NewSubscription(ctx, *subName, client.Topic(*topicName), 10*time.Second, push)
+		if err != nil {
+			log.Fatalf("Failed to create push subscription: %v", err)
+		}
+		fmt.Printf("Created push subscription: %v\n", sub)
+	}
+
+	http.HandleFunc("/", handlePush)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
+
+// pushRequest is the envelope Pub/Sub wraps push messages in. Message.Data
+// is base64-encoded on the wire; encoding/json decodes it into []byte for
+// us automatically.
+type pushRequest struct {
+	Message struct {
+		Data       []byte            `json:"data"`
+		Attributes map[string]string `json:"attributes"`
+		MessageID  string            `json:"messageId"`
+	} `json:"message"`
+	Subscription string `json:"subscription"`
+}
+
+// handlePush decodes a Pub/Sub push envelope and acks it by replying with a
+// 2xx status, or nacks it with a 5xx status so Pub/Sub retries delivery.
+func handlePush(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read request body: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var req pushRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to decode push message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	fmt.Printf("Got push message %s on %s: %q\n", req.Message.MessageID, req.Subscription, string(req.Message.Data))
+	w.WriteHeader(http.StatusOK)
+}
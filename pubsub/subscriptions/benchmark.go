@@ -0,0 +1,145 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsub/internal/pubsubcli"
+)
+
+func cmdBenchmark(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to pull from")
+	batch := fs.Int("batch", 10, "max number of messages to prefetch per pull")
+	concurrency := fs.Int("concurrency", 1, "number of parallel iterator goroutines")
+	maxExtension := fs.Duration("max-extension", 10*time.Minute, "max duration to extend a message's ack deadline before redelivery")
+	interval := fs.Duration("interval", 5*time.Second, "reporting interval")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	benchmark(client, *subName, *batch, *concurrency, *maxExtension, *interval)
+}
+
+// benchmark pulls messages from the named subscription using concurrency
+// parallel iterators and reports msg/sec, MB/sec, and ack latency
+// percentiles to stderr every interval, until the process is killed or
+// every iterator has stopped.
+// Ack latency is measured end-to-end, from msg.PublishTime to the moment
+// it.Next() delivers the message to this process; msg.Done only records a
+// local ack/nack decision that the iterator batches into a background ack
+// RPC, so timing around it would measure a function call, not an ack.
+func benchmark(c *pubsub.Client, name string, batch, concurrency int, maxExtension, interval time.Duration) {
+	sub := c.Subscription(name)
+	ctx := context.Background()
+
+	var st benchStats
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			it, err := sub.Pull(ctx, pubsub.MaxPrefetch(batch), pubsub.MaxExtension(maxExtension))
+			if err != nil {
+				log.Printf("Failed to pull from subscription: %v", err)
+				return
+			}
+			defer it.Stop()
+
+			for {
+				msg, err := it.Next()
+				if err == pubsub.Done {
+					return
+				}
+				if err != nil {
+					log.Printf("Failed when iterating on messages: %v", err)
+					return
+				}
+				st.record(len(msg.Data), time.Since(msg.PublishTime))
+				msg.Done(true)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			st.report(now.Sub(last))
+			last = now
+		case <-done:
+			return
+		}
+	}
+}
+
+// benchStats accumulates message counts, byte counts, and ack latencies
+// for a reporting window.
+type benchStats struct {
+	msgs  int64
+	bytes int64
+
+	mu   sync.Mutex
+	lats []time.Duration
+}
+
+func (s *benchStats) record(size int, lat time.Duration) {
+	atomic.AddInt64(&s.msgs, 1)
+	atomic.AddInt64(&s.bytes, int64(size))
+	s.mu.Lock()
+	s.lats = append(s.lats, lat)
+	s.mu.Unlock()
+}
+
+// report prints the rates and latency percentiles accumulated since the
+// last report; window is the actual elapsed time since then, not the
+// configured reporting interval, since ticks can fire late under load.
+func (s *benchStats) report(window time.Duration) {
+	msgs := atomic.SwapInt64(&s.msgs, 0)
+	bytes := atomic.SwapInt64(&s.bytes, 0)
+
+	s.mu.Lock()
+	lats := s.lats
+	s.lats = nil
+	s.mu.Unlock()
+
+	sort.Slice(lats, func(i, j int) bool { return lats[i] < lats[j] })
+
+	fmt.Fprintf(os.Stderr, "%8.1f msg/s  %8.2f MB/s  ack p50=%v p95=%v\n",
+		float64(msgs)/window.Seconds(),
+		float64(bytes)/window.Seconds()/1e6,
+		percentile(lats, 0.50), percentile(lats, 0.95))
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
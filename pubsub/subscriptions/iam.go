@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"golang.org/x/net/context"
+
+	"cloud.google.com/go/iam"
+	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsub/internal/pubsubcli"
+)
+
+func cmdIAMGet(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("iam-get", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	policy, err := getPolicy(client, *subName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, role := range policy.Roles() {
+		for _, member := range policy.Members(role) {
+			fmt.Printf("%s: %s\n", role, member)
+		}
+	}
+}
+
+func cmdIAMAddMember(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("iam-add-member", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription")
+	role := fs.String("role", "", "IAM role to grant, e.g. roles/pubsub.subscriber")
+	member := fs.String("member", "", "member to grant the role to, e.g. user:alice@example.com")
+	fs.Parse(args)
+
+	if *subName == "" || *role == "" || *member == "" {
+		log.Fatal("-sub, -role, and -member are required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	if err := setPolicy(client, *subName, *member, iam.RoleName(*role)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cmdIAMTest(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("iam-test", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription")
+	permission := fs.String("permission", "", "permission to test, e.g. pubsub.subscriptions.consume")
+	fs.Parse(args)
+
+	if *subName == "" || *permission == "" {
+		log.Fatal("-sub and -permission are required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	granted, err := testPermissions(client, *subName, *permission)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(granted)
+}
+
+// getPolicy returns the IAM policy for the named subscription.
+func getPolicy(c *pubsub.Client, name string) (*iam.Policy, error) {
+	ctx := context.Background()
+	policy, err := c.Subscription(name).IAM().Policy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch IAM policy: %v", err)
+	}
+	return policy, nil
+}
+
+// setPolicy grants role to member on the named subscription.
+func setPolicy(c *pubsub.Client, name, member string, role iam.RoleName) error {
+	ctx := context.Background()
+	sub := c.Subscription(name)
+	policy, err := sub.IAM().Policy(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch IAM policy: %v", err)
+	}
+	policy.Add(member, role)
+	if err := sub.IAM().SetPolicy(ctx, policy); err != nil {
+		return fmt.Errorf("Failed to set IAM policy: %v", err)
+	}
+	return nil
+}
+
+// testPermissions reports whether the caller holds permission on the named
+// subscription.
+func testPermissions(c *pubsub.Client, name, permission string) (bool, error) {
+	ctx := context.Background()
+	granted, err := c.Subscription(name).IAM().TestPermissions(ctx, []string{permission})
+	if err != nil {
+		return false, fmt.Errorf("Failed to test IAM permissions: %v", err)
+	}
+	return len(granted) > 0, nil
+}
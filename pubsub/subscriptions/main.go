@@ -7,6 +7,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -16,54 +17,167 @@ import (
 	"golang.org/x/net/context"
 
 	"cloud.google.com/go/pubsub"
+	"github.com/GoogleCloudPlatform/golang-samples/pubsub/internal/pubsubcli"
 	// [END imports]
 )
 
+const usage = `Usage: subscriptions <command> [arguments]
+
+Commands:
+  list                                   List subscriptions in a project
+  create -sub=name -topic=name           Create a subscription
+                   -push-endpoint=u -push-attr=k=v  Optionally deliver by push, with push attributes
+  pull -sub=name                         Pull and print messages from a subscription
+  delete -sub=name                       Delete a subscription
+  exists -sub=name                       Report whether a subscription exists
+  iam-get -sub=name                             Print the IAM policy for a subscription
+  iam-add-member -sub=name -role=r -member=m    Add a member to a subscription's IAM policy
+  iam-test -sub=name -permission=p              Report whether the caller holds permission p on a subscription
+  benchmark -sub=name                           Pull continuously and report throughput and ack latency
+  configure-push -sub=name -endpoint=u   Switch a subscription to push delivery, or back to pull if -endpoint is empty
+
+All commands accept -p to set the GCP project ID, defaulting to the
+GOOGLE_CLOUD_PROJECT environment variable; -emulator to target a local
+Pub/Sub emulator or pstest server instead of the live API, defaulting to
+the PUBSUB_EMULATOR_HOST environment variable; and -json to authenticate
+with a service account JSON key file instead of Application Default
+Credentials.
+`
+
 func main() {
-	ctx := context.Background()
-	// [START auth]
-	proj := os.Getenv("GOOGLE_CLOUD_PROJECT")
-	if proj == "" {
-		fmt.Fprintf(os.Stderr, "GOOGLE_CLOUD_PROJECT environment variable must be set.\n")
-		os.Exit(1)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
-	client, err := pubsub.NewClient(ctx, proj)
-	if err != nil {
-		log.Fatalf("Could not create pubsub Client: %v", err)
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "list":
+		cmdList(ctx, os.Args[2:])
+	case "create":
+		cmdCreate(ctx, os.Args[2:])
+	case "pull":
+		cmdPull(ctx, os.Args[2:])
+	case "delete":
+		cmdDelete(ctx, os.Args[2:])
+	case "exists":
+		cmdExists(ctx, os.Args[2:])
+	case "iam-get":
+		cmdIAMGet(ctx, os.Args[2:])
+	case "iam-add-member":
+		cmdIAMAddMember(ctx, os.Args[2:])
+	case "iam-test":
+		cmdIAMTest(ctx, os.Args[2:])
+	case "benchmark":
+		cmdBenchmark(ctx, os.Args[2:])
+	case "configure-push":
+		cmdConfigurePush(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
-	// [END auth]
+}
+
+func cmdList(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	fs.Parse(args)
 
-	// Print all the subscriptions in the project.
-	fmt.Println("Listing all subscriptions from the project:")
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
 	subs, err := list(client)
 	if err != nil {
 		log.Fatal(err)
 	}
 	for _, sub := range subs {
-		fmt.Printf("%v\n", sub.Name())
+		fmt.Println(sub.Name())
 	}
+}
 
-	const topic = "example-topic"
-	// Create a topic to subscribe to.
-	t, err := client.NewTopic(ctx, topic)
-	if err != nil {
-		log.Fatalf("Failed to create the topic: %v", err)
+func cmdCreate(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to create")
+	topicName := fs.String("topic", "", "name of the topic to subscribe to")
+	ackDeadline := fs.Duration("ack-deadline", 10*time.Second, "ack deadline for the subscription")
+	pushEndpoint := fs.String("push-endpoint", "", "if set, create a push subscription delivering to this URL")
+	pushAttrs := pubsubcli.AttrFlag{}
+	fs.Var(pushAttrs, "push-attr", "push endpoint attribute key=value; repeatable, only used with -push-endpoint")
+	fs.Parse(args)
+
+	if *subName == "" || *topicName == "" {
+		log.Fatal("-sub and -topic are required")
 	}
-	defer t.Delete(ctx) // cleanup when finished using t.
 
-	const sub = "example-subscription"
-	// Create a new subscription.
-	if err := create(client, sub, t); err != nil {
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	if err := create(client, *subName, client.Topic(*topicName), *ackDeadline, *pushEndpoint, pushAttrs); err != nil {
 		log.Fatal(err)
 	}
+}
+
+func cmdPull(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("pull", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to pull from")
+	n := fs.Int("n", 10, "number of messages to pull before exiting")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	if err := pullMsgs(client, *subName, *n); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cmdDelete(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to delete")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
+
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	if err := delete(client, *subName); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cmdExists(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("exists", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to check")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
 
-	// Pull messages via the subscription.
-	if err := pullMsgs(client, sub, t); err != nil {
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	ok, err := exists(client, *subName)
+	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println(ok)
+}
+
+func cmdConfigurePush(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("configure-push", flag.ExitOnError)
+	proj, emulator, jsonKey := pubsubcli.CommonFlags(fs)
+	subName := fs.String("sub", "", "name of the subscription to update")
+	endpoint := fs.String("endpoint", "", "push endpoint URL; if empty, switch the subscription back to pull")
+	fs.Parse(args)
+
+	if *subName == "" {
+		log.Fatal("-sub is required")
+	}
 
-	// Delete the subscription.
-	if err := delete(client, sub); err != nil {
+	client := pubsubcli.NewClient(ctx, *proj, *emulator, *jsonKey)
+	if err := setPushConfig(client, *subName, *endpoint); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -87,20 +201,9 @@ func list(c *pubsub.Client) ([]*pubsub.Subscription, error) {
 	return subs, nil
 }
 
-func pullMsgs(c *pubsub.Client, name string, topic *pubsub.Topic) error {
+func pullMsgs(c *pubsub.Client, name string, n int) error {
 	ctx := context.Background()
 
-	const n = 10
-	// publish 10 messages on the topic.
-	for i := 0; i < n; i++ {
-		_, err := topic.Publish(ctx, &pubsub.Message{
-			Data: []byte(fmt.Sprintf("hello world #%d", i)),
-		})
-		if err != nil {
-			return fmt.Errorf("Failed to publish message #%d: %v", i, err)
-		}
-	}
-
 	// [START pull_messages]
 	sub := c.Subscription(name)
 	it, err := sub.Pull(ctx)
@@ -109,7 +212,6 @@ func pullMsgs(c *pubsub.Client, name string, topic *pubsub.Topic) error {
 	}
 	defer it.Stop()
 
-	// Consume 10 messages.
 	for i := 0; i < n; i++ {
 		msg, err := it.Next()
 		if err == pubsub.Done {
@@ -125,10 +227,14 @@ func pullMsgs(c *pubsub.Client, name string, topic *pubsub.Topic) error {
 	return nil
 }
 
-func create(c *pubsub.Client, name string, topic *pubsub.Topic) error {
+func create(c *pubsub.Client, name string, topic *pubsub.Topic, ackDeadline time.Duration, pushEndpoint string, pushAttrs map[string]string) error {
 	ctx := context.Background()
 	// [START create_subscription]
-	sub, err := c.NewSubscription(ctx, name, topic, 10*time.Second, nil)
+	var push *pubsub.PushConfig
+	if pushEndpoint != "" {
+		push = &pubsub.PushConfig{Endpoint: pushEndpoint, Attributes: pushAttrs}
+	}
+	sub, err := c.NewSubscription(ctx, name, topic, ackDeadline, push)
 	if err != nil {
 		return fmt.Errorf("Failed to create a new subscription: %v", err)
 	}
@@ -148,3 +254,27 @@ func delete(c *pubsub.Client, name string) error {
 	// [END delete_subscription]
 	return nil
 }
+
+// setPushConfig switches name between push and pull delivery. An empty
+// endpoint clears the subscription's push config, returning it to pull.
+func setPushConfig(c *pubsub.Client, name, endpoint string) error {
+	ctx := context.Background()
+	sub := c.Subscription(name)
+	cfg := pubsub.SubscriptionConfigToUpdate{
+		PushConfig: &pubsub.PushConfig{Endpoint: endpoint},
+	}
+	if _, err := sub.Update(ctx, cfg); err != nil {
+		return fmt.Errorf("Failed to update subscription push config: %v", err)
+	}
+	return nil
+}
+
+func exists(c *pubsub.Client, name string) (bool, error) {
+	ctx := context.Background()
+	sub := c.Subscription(name)
+	ok, err := sub.Exists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("Failed to check whether subscription exists: %v", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2016 Google Inc. All rights reserved.
+// Use of this source code is governed by the Apache 2.0
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+// newTestClient connects to an in-process pstest server, so these tests
+// exercise list, create, pullMsgs, and delete without touching the real
+// Pub/Sub API.
+func newTestClient(t *testing.T) (*pubsub.Client, func()) {
+	t.Helper()
+	ctx := context.Background()
+
+	srv := pstest.NewServer()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial: %v", err)
+	}
+	client, err := pubsub.NewClient(ctx, "test-project", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("pubsub.NewClient: %v", err)
+	}
+	return client, func() {
+		client.Close()
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestCreateListPullDelete(t *testing.T) {
+	ctx := context.Background()
+	client, cleanup := newTestClient(t)
+	defer cleanup()
+
+	topic, err := client.NewTopic(ctx, "test-topic")
+	if err != nil {
+		t.Fatalf("NewTopic: %v", err)
+	}
+
+	const subName = "test-sub"
+	if err := create(client, subName, topic, 10*time.Second, "", nil); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	ok, err := exists(client, subName)
+	if err != nil {
+		t.Fatalf("exists: %v", err)
+	}
+	if !ok {
+		t.Fatal("exists = false, want true after create")
+	}
+
+	subs, err := list(client)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var found bool
+	for _, s := range subs {
+		if s.Name() == subName {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("list did not return %q", subName)
+	}
+
+	if _, err := topic.Publish(ctx, &pubsub.Message{Data: []byte("hello")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := pullMsgs(client, subName, 1); err != nil {
+		t.Fatalf("pullMsgs: %v", err)
+	}
+
+	if err := delete(client, subName); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	ok, err = exists(client, subName)
+	if err != nil {
+		t.Fatalf("exists: %v", err)
+	}
+	if ok {
+		t.Fatal("exists = true, want false after delete")
+	}
+}